@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,7 +19,10 @@ import (
 	"example.com/v2/internal/middlewares"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -49,19 +56,26 @@ func main() {
 	}
 	log.Info().Msgf("Connected to PostgreSQL")
 
-	// Create event queue and worker
-	eventQueue := make(chan event.OrderCreated, 100)
-	eventWorker := event.NewEventWorker(eventQueue, log.Logger)
+	// Create event queue and worker pool, which fans events out to live
+	// WebSocket subscribers
+	eventQueue := make(chan event.Event, 100)
+	eventWorker := event.NewEventWorker(eventQueue, eventWorkerPoolSize(), eventEnqueueTimeout(), log.Logger)
 
 	// Context for graceful shutdown and event worker
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start background event processing worker
+	// Start background event processing worker pool
 	eventWorker.StartEventWorker(ctx)
 
+	// The outbox poller is the source of truth for reliable delivery to the
+	// external event sink; it also hands newly-published events to
+	// eventWorker for live WebSocket fan-out.
+	outboxPoller := event.NewOutboxPoller(db, newEventPublisher(), eventWorker, outboxPollInterval(), log.Logger)
+	outboxPoller.Start(ctx)
+
 	// Create server instance
-	srv := server.NewServer(db, eventQueue, log.Logger)
+	srv := server.NewServer(db, eventWorker, subscriptionTokenSecret(), log.Logger)
 
 	// Setup Gin and routes using generated router binder
 	router := gin.Default()
@@ -70,6 +84,22 @@ func main() {
 	// Register routes with handler
 	api.RegisterHandlers(router, srv)
 
+	// Expose events_enqueued_total/events_dropped_total/events_processed_total
+	// for scraping.
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// WebSocket subscriptions aren't part of the OpenAPI-generated router,
+	// so they're wired up by hand alongside it.
+	router.GET("/ws/orders", srv.SubscribeOrders)
+	router.GET("/ws/orders/:id", func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		srv.SubscribeOrderByID(c, id)
+	})
+
 	// HTTP server
 	httpServer := &http.Server{
 		Addr:    ":" + port,
@@ -99,8 +129,110 @@ func main() {
 		log.Fatal().Msgf("Server forced to shutdown: %v", err)
 	}
 
-	// Cancel event worker context to stop background processing
+	// Cancel event worker context to stop background processing, then wait
+	// for in-flight events to finish before exiting.
 	cancel()
+	eventWorker.Drain(10 * time.Second)
 
 	log.Info().Msgf("Server exiting gracefully")
 }
+
+// subscriptionTokenSecret reads SUBSCRIPTION_TOKEN_SECRET (hex-encoded) to
+// sign WebSocket subscription tokens minted by CreateOrder. If unset, a
+// random secret is generated for this process only, so previously issued
+// tokens stop verifying across a restart; set the env var in any
+// multi-replica or long-lived deployment.
+func subscriptionTokenSecret() []byte {
+	if raw := os.Getenv("SUBSCRIPTION_TOKEN_SECRET"); raw != "" {
+		if secret, err := hex.DecodeString(raw); err == nil {
+			return secret
+		}
+		log.Warn().Msg("Invalid SUBSCRIPTION_TOKEN_SECRET, generating a random secret for this process")
+	} else {
+		log.Warn().Msg("SUBSCRIPTION_TOKEN_SECRET not set, generating a random secret for this process; subscription tokens will stop verifying on restart")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatal().Msgf("Failed to generate subscription token secret: %v", err)
+	}
+	return secret
+}
+
+// outboxPollInterval reads OUTBOX_POLL_INTERVAL (a Go duration string, e.g.
+// "2s") or falls back to a sane default.
+func outboxPollInterval() time.Duration {
+	if raw := os.Getenv("OUTBOX_POLL_INTERVAL"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil {
+			return d
+		}
+		log.Warn().Str("value", raw).Msg("Invalid OUTBOX_POLL_INTERVAL, using default")
+	}
+	return 2 * time.Second
+}
+
+// eventWorkerPoolSize reads EVENT_WORKER_POOL_SIZE or falls back to a sane
+// default number of WebSocket fan-out worker goroutines.
+func eventWorkerPoolSize() int {
+	if raw := os.Getenv("EVENT_WORKER_POOL_SIZE"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return n
+		}
+		log.Warn().Str("value", raw).Msg("Invalid EVENT_WORKER_POOL_SIZE, using default")
+	}
+	return 4
+}
+
+// eventEnqueueTimeout reads EVENT_ENQUEUE_TIMEOUT (a Go duration string,
+// e.g. "500ms") or falls back to a sane default for how long TryEnqueue
+// waits for room in the event queue before dropping an event.
+func eventEnqueueTimeout() time.Duration {
+	if raw := os.Getenv("EVENT_ENQUEUE_TIMEOUT"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil {
+			return d
+		}
+		log.Warn().Str("value", raw).Msg("Invalid EVENT_ENQUEUE_TIMEOUT, using default")
+	}
+	return 500 * time.Millisecond
+}
+
+// newEventPublisher selects the EventPublisher backend via the EVENT_SINK
+// env var ("log" (default), "redis", or "kafka") and wraps it with
+// retry-then-dead-letter semantics.
+func newEventPublisher() event.EventPublisher {
+	var publisher event.EventPublisher
+
+	switch os.Getenv("EVENT_SINK") {
+	case "redis":
+		redisAddr := os.Getenv("REDIS_ADDR")
+		if redisAddr == "" {
+			redisAddr = "localhost:6379"
+		}
+		redisChannel := os.Getenv("REDIS_CHANNEL")
+		if redisChannel == "" {
+			redisChannel = "orders.events"
+		}
+		client := redis.NewClient(&redis.Options{Addr: redisAddr})
+		publisher = event.NewRedisPublisher(client, redisChannel)
+		log.Info().Str("addr", redisAddr).Str("channel", redisChannel).Msg("Using Redis event sink")
+	case "kafka":
+		kafkaBrokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+		if len(kafkaBrokers) == 0 || kafkaBrokers[0] == "" {
+			kafkaBrokers = []string{"localhost:9092"}
+		}
+		kafkaTopic := os.Getenv("KAFKA_TOPIC")
+		if kafkaTopic == "" {
+			kafkaTopic = "orders.events"
+		}
+		publisher = event.NewKafkaPublisher(kafkaBrokers, kafkaTopic)
+		log.Info().Strs("brokers", kafkaBrokers).Str("topic", kafkaTopic).Msg("Using Kafka event sink")
+	default:
+		publisher = event.NewLogPublisher(log.Logger)
+	}
+
+	deadLetterPath := os.Getenv("EVENT_DEAD_LETTER_FILE")
+	if deadLetterPath == "" {
+		deadLetterPath = "events-dead-letter.log"
+	}
+	return event.NewDeadLetterPublisher(publisher, deadLetterPath, log.Logger)
+}
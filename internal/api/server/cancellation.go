@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"example.com/v2/internal/api"
+	"example.com/v2/internal/event"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// Order status values. OPEN is the only state a new order is created in;
+// CANCELLED and FULFILLED are terminal.
+const (
+	statusOpen      api.OrderStatus = "OPEN"
+	statusCancelled api.OrderStatus = "CANCELLED"
+	statusFulfilled api.OrderStatus = "FULFILLED"
+)
+
+// CancelOrder handles DELETE /orders/{id}. Only an OPEN order can be
+// cancelled; cancelling an already-CANCELLED or FULFILLED order returns 409.
+func (s *Server) CancelOrder(c *gin.Context, id openapi_types.UUID) {
+	logger := s.logger.With().Str("handler", "CancelOrder").Logger()
+	ctx := c.Request.Context()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to begin transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	defer tx.Rollback()
+
+	order, currentStatus, err := lockOrderForUpdate(ctx, tx, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch order")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if currentStatus != statusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only an OPEN order can be cancelled"})
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET status = $1 WHERE id = $2`, statusCancelled, id); err != nil {
+		logger.Error().Err(err).Msg("Failed to update order status")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	cancelled := statusCancelled
+	order.Status = &cancelled
+
+	if err := recordOutboxEvent(ctx, tx, "OrderCancelled", id, event.NewOrderCancelledPayload, order); err != nil {
+		logger.Error().Err(err).Msg("Failed to record outbox event")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("Failed to commit transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	logger.Info().Str("order_id", id.String()).Msg("Order cancelled")
+	c.JSON(http.StatusOK, order)
+}
+
+// UpdateOrder handles PATCH /orders/{id}, changing the order's item. Only an
+// OPEN order can be updated; updating a CANCELLED or FULFILLED order returns
+// 409.
+func (s *Server) UpdateOrder(c *gin.Context, id openapi_types.UUID) {
+	logger := s.logger.With().Str("handler", "UpdateOrder").Logger()
+	ctx := c.Request.Context()
+
+	bodyBytes, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	var req api.UpdateOrderJSONRequestBody
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		logger.Error().Err(err).Msg("Invalid input")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to begin transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	defer tx.Rollback()
+
+	order, currentStatus, err := lockOrderForUpdate(ctx, tx, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch order")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if currentStatus != statusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only an OPEN order can be updated"})
+		return
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET item = $1 WHERE id = $2`, req.Item, id); err != nil {
+		logger.Error().Err(err).Msg("Failed to update order")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	order.Item = &req.Item
+
+	if err := recordOutboxEvent(ctx, tx, "OrderUpdated", id, event.NewOrderUpdatedPayload, order); err != nil {
+		logger.Error().Err(err).Msg("Failed to record outbox event")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("Failed to commit transaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	logger.Info().Str("order_id", id.String()).Msg("Order updated")
+	c.JSON(http.StatusOK, order)
+}
+
+// lockOrderForUpdate fetches an order and locks its row so a concurrent
+// cancel/update can't race the caller's transition check.
+func lockOrderForUpdate(ctx context.Context, tx *sql.Tx, id openapi_types.UUID) (api.OrderResponse, api.OrderStatus, error) {
+	var order api.OrderResponse
+	var status api.OrderStatus
+
+	query := `SELECT id, customer_name, item, created_at, status FROM orders WHERE id = $1 FOR UPDATE`
+	row := tx.QueryRowContext(ctx, query, id)
+	if err := row.Scan(&order.Id, &order.CustomerName, &order.Item, &order.CreatedAt, &status); err != nil {
+		return api.OrderResponse{}, "", err
+	}
+	order.Status = &status
+
+	return order, status, nil
+}
+
+// recordOutboxEvent marshals the order's current state into the stable
+// outbox payload for eventType and inserts it alongside the domain change in
+// the same transaction.
+func recordOutboxEvent(
+	ctx context.Context,
+	tx *sql.Tx,
+	eventType string,
+	orderID openapi_types.UUID,
+	payloadFor func(api.OrderResponse) ([]byte, error),
+	order api.OrderResponse,
+) error {
+	payload, err := payloadFor(order)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO order_events (id, aggregate_id, type, payload, created_at) VALUES ($1, $2, $3, $4, now())`
+	_, err = tx.ExecContext(ctx, query, uuid.New(), orderID, eventType, payload)
+	return err
+}
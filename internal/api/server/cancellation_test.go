@@ -0,0 +1,151 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCancelGinTestContext(id uuid.UUID) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/orders/"+id.String(), nil)
+	return c, w
+}
+
+func setupUpdateGinTestContext(id uuid.UUID, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/orders/"+id.String(), strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c, w
+}
+
+func TestCancelOrder_OpenToCancelled(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	orderID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id = .* FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at", "status"}).
+			AddRow(orderID, "John Doe", "Book", time.Now(), "OPEN"))
+	mock.ExpectExec("UPDATE orders SET status").
+		WithArgs(statusCancelled, orderID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_events").
+		WithArgs(sqlmock.AnyArg(), orderID, "OrderCancelled", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	c, w := setupCancelGinTestContext(orderID)
+	srv.CancelOrder(c, orderID)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCancelOrder_AlreadyCancelled_Conflict(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	orderID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id = .* FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at", "status"}).
+			AddRow(orderID, "John Doe", "Book", time.Now(), "CANCELLED"))
+	mock.ExpectRollback()
+
+	c, w := setupCancelGinTestContext(orderID)
+	srv.CancelOrder(c, orderID)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCancelOrder_Fulfilled_Conflict(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	orderID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id = .* FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at", "status"}).
+			AddRow(orderID, "John Doe", "Book", time.Now(), "FULFILLED"))
+	mock.ExpectRollback()
+
+	c, w := setupCancelGinTestContext(orderID)
+	srv.CancelOrder(c, orderID)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCancelOrder_NotFound(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	orderID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id = .* FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	c, w := setupCancelGinTestContext(orderID)
+	srv.CancelOrder(c, orderID)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateOrder_OpenSucceeds(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	orderID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id = .* FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at", "status"}).
+			AddRow(orderID, "John Doe", "Book", time.Now(), "OPEN"))
+	mock.ExpectExec("UPDATE orders SET item").
+		WithArgs("Pen", orderID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_events").
+		WithArgs(sqlmock.AnyArg(), orderID, "OrderUpdated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	c, w := setupUpdateGinTestContext(orderID, `{"item": "Pen"}`)
+	srv.UpdateOrder(c, orderID)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUpdateOrder_Cancelled_Conflict(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	orderID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id = .* FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at", "status"}).
+			AddRow(orderID, "John Doe", "Book", time.Now(), "CANCELLED"))
+	mock.ExpectRollback()
+
+	c, w := setupUpdateGinTestContext(orderID, `{"item": "Pen"}`)
+	srv.UpdateOrder(c, orderID)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// idempotencyHeader is the header clients set to make a POST /orders retry
+// safe, per the Idempotency-Key draft (IETF draft-ietf-httpapi-idempotency-key-header).
+const idempotencyHeader = "Idempotency-Key"
+
+// hashRequestBody returns a stable hex digest of a request body, used to
+// detect an Idempotency-Key being replayed with a different body.
+func hashRequestBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// storedIdempotentResponse is a previously recorded response for a
+// (customer_name, key) pair. There's no standalone customer entity in this
+// schema, so customer_name doubles as the customer identifier.
+type storedIdempotentResponse struct {
+	BodyHash   string
+	StatusCode int
+	Body       []byte
+}
+
+// claimIdempotencyKey tries to atomically reserve customerName+key for this
+// request's response, relying on the unique constraint on
+// idempotency_keys(customer_name, key) to arbitrate concurrent retries of
+// the same key instead of racing a separate lookup-then-insert. It reports
+// whether this call won the race; tx is rolled back by the caller either
+// way if it loses, so the loser must read the winner's response via
+// lockIdempotencyKey instead.
+func claimIdempotencyKey(ctx context.Context, tx *sql.Tx, customerName, key, bodyHash string, statusCode int, body []byte, createdAt time.Time) (bool, error) {
+	query := `INSERT INTO idempotency_keys (customer_name, key, body_hash, status_code, response_body, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6)
+	          ON CONFLICT (customer_name, key) DO NOTHING`
+	res, err := tx.ExecContext(ctx, query, customerName, key, bodyHash, statusCode, body, createdAt)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// lockIdempotencyKey returns the stored response for customerName+key,
+// blocking on the row lock held by a concurrent claimIdempotencyKey call
+// until that call's transaction commits or rolls back. ok=false means the
+// row was never committed (the concurrent request rolled back), so the key
+// is free again and the caller should retry its own claim.
+func lockIdempotencyKey(ctx context.Context, tx *sql.Tx, customerName, key string) (storedIdempotentResponse, bool, error) {
+	var stored storedIdempotentResponse
+	query := `SELECT body_hash, status_code, response_body FROM idempotency_keys WHERE customer_name = $1 AND key = $2 FOR UPDATE`
+	row := tx.QueryRowContext(ctx, query, customerName, key)
+	err := row.Scan(&stored.BodyHash, &stored.StatusCode, &stored.Body)
+	if err == sql.ErrNoRows {
+		return storedIdempotentResponse{}, false, nil
+	}
+	if err != nil {
+		return storedIdempotentResponse{}, false, err
+	}
+	return stored, true, nil
+}
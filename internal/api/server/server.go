@@ -2,7 +2,9 @@ package server
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -16,16 +18,21 @@ import (
 
 // Server is your implementation of ServerInterface
 type Server struct {
-	db         *sql.DB
-	eventQueue chan event.OrderCreated
-	logger     zerolog.Logger
+	db                 *sql.DB
+	eventWorker        event.EventWorker
+	subscriptionSecret []byte
+	logger             zerolog.Logger
 }
 
-func NewServer(db *sql.DB, eventQueue chan event.OrderCreated, logger zerolog.Logger) *Server {
+// NewServer builds a Server. subscriptionSecret signs the tokens CreateOrder
+// hands out for WebSocket subscriptions (see mintSubscriptionToken); it must
+// be stable across restarts for previously issued tokens to keep verifying.
+func NewServer(db *sql.DB, eventWorker event.EventWorker, subscriptionSecret []byte, logger zerolog.Logger) *Server {
 	return &Server{
-		db:         db,
-		eventQueue: eventQueue,
-		logger:     logger,
+		db:                 db,
+		eventWorker:        eventWorker,
+		subscriptionSecret: subscriptionSecret,
+		logger:             logger,
 	}
 }
 
@@ -34,68 +41,155 @@ func (s *Server) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
-// CreateOrder handles POST /orders
+// maxIdempotencyClaimAttempts bounds how many times CreateOrder retries
+// claiming an Idempotency-Key after losing a race to a concurrent request
+// that then rolled back (e.g. on a transient DB error), rather than
+// retrying forever.
+const maxIdempotencyClaimAttempts = 3
+
+// subscriptionTokenHeader carries the token CreateOrder mints for the new
+// order's customer_name; the caller presents it back to SubscribeOrders or
+// SubscribeOrderByID to subscribe to that customer's events.
+const subscriptionTokenHeader = "X-Subscription-Token"
+
+// CreateOrder handles POST /orders. If the caller sets an Idempotency-Key
+// header, a retried request with the same key and body replays the
+// original response instead of creating a second order. Concurrent requests
+// with the same key are arbitrated by claimIdempotencyKey's unique
+// constraint rather than a separate lookup-then-insert, which would let two
+// racing retries both see "not found" and create duplicate orders.
 func (s *Server) CreateOrder(c *gin.Context) {
 	logger := s.logger.With().Str("handler", "CreateOrder").Logger()
+	ctx := c.Request.Context()
 
-	var req api.CreateOrderJSONRequestBody
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Error().Err(err).Msg("Invalid input")
+	bodyBytes, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read request body")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	createdAt := time.Now().UTC()
-	var existingOrder api.OrderResponse
-
-	query := `SELECT id, customer_name, item, created_at FROM orders 
-              WHERE customer_name=$1 AND item=$2 AND created_at=$3 LIMIT 1`
-	row := s.db.QueryRowContext(c.Request.Context(), query, req.CustomerName, req.Item, createdAt)
-	err := row.Scan(&existingOrder.Id, &existingOrder.CustomerName, &existingOrder.Item, &existingOrder.CreatedAt)
-	if err == nil {
-		// Found existing order: return it with 200 OK
-		logger.Info().
-			Str("customer_name", req.CustomerName).
-			Str("item", req.Item).
-			Time("created_at", createdAt).
-			Msg("Duplicate order detected - returning existing order")
-		c.JSON(http.StatusOK, existingOrder)
-		return
-	} else if err != sql.ErrNoRows {
-		// Database error - log and return error
-		logger.Error().Err(err).Msg("DB error checking existing order")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	var req api.CreateOrderJSONRequestBody
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		logger.Error().Err(err).Msg("Invalid input")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	id := openapi_types.UUID(uuid.New())
-	// Insert into DB
-	query = `INSERT INTO orders (id, customer_name, item, created_at) VALUES ($1, $2, $3, $4)`
-	if _, err := s.db.ExecContext(c.Request.Context(), query, id, req.CustomerName, req.Item, createdAt); err != nil {
-		logger.Error().Err(err).Msg("DB insert failed")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err,
-			"msg":   "Failed to create order",
-		})
-		return
-	}
+	idempotencyKey := c.GetHeader(idempotencyHeader)
+	bodyHash := hashRequestBody(bodyBytes)
 
-	logger.Info().
-		Str("order_id", id.String()).
-		Str("customer", req.CustomerName).
-		Msg("Order created")
-
-	// Return created order response
+	createdAt := time.Now().UTC()
+	id := openapi_types.UUID(uuid.New())
+	status := statusOpen
 	resp := api.OrderResponse{
 		Id:           &id,
 		CustomerName: &req.CustomerName,
 		Item:         &req.Item,
 		CreatedAt:    &createdAt,
+		Status:       &status,
+	}
+
+	respBody, err := json.Marshal(resp)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to marshal order response")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	subscriptionToken := mintSubscriptionToken(s.subscriptionSecret, req.CustomerName)
+
+	for attempt := 1; ; attempt++ {
+		// Insert the order, its outbox event and (if present) the
+		// idempotency record in the same transaction so none of them can be
+		// lost to a crash partway through.
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to begin transaction")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		if idempotencyKey != "" {
+			claimed, err := claimIdempotencyKey(ctx, tx, req.CustomerName, idempotencyKey, bodyHash, http.StatusCreated, respBody, createdAt)
+			if err != nil {
+				tx.Rollback()
+				logger.Error().Err(err).Msg("Failed to claim idempotency key")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+			if !claimed {
+				// Someone else already claimed this key; block on its row
+				// lock until it commits or rolls back, then either replay
+				// its response or, if it rolled back, retry our own claim.
+				stored, ok, err := lockIdempotencyKey(ctx, tx, req.CustomerName, idempotencyKey)
+				tx.Rollback()
+				if err != nil {
+					logger.Error().Err(err).Msg("Failed to look up idempotency key")
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+					return
+				}
+				if !ok {
+					if attempt < maxIdempotencyClaimAttempts {
+						continue
+					}
+					logger.Error().Str("idempotency_key", idempotencyKey).Msg("Gave up claiming idempotency key after repeated concurrent rollback")
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+					return
+				}
+				if stored.BodyHash != bodyHash {
+					c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request body"})
+					return
+				}
+				logger.Info().Str("idempotency_key", idempotencyKey).Msg("Replaying stored response for idempotency key")
+				c.Header(subscriptionTokenHeader, subscriptionToken)
+				c.Data(stored.StatusCode, "application/json", stored.Body)
+				return
+			}
+		}
+
+		orderQuery := `INSERT INTO orders (id, customer_name, item, created_at, status) VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.ExecContext(ctx, orderQuery, id, req.CustomerName, req.Item, createdAt, status); err != nil {
+			tx.Rollback()
+			logger.Error().Err(err).Msg("DB insert failed")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err,
+				"msg":   "Failed to create order",
+			})
+			return
+		}
+
+		eventPayload, err := event.NewOrderCreatedPayload(resp)
+		if err != nil {
+			tx.Rollback()
+			logger.Error().Err(err).Msg("Failed to marshal order event payload")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		outboxQuery := `INSERT INTO order_events (id, aggregate_id, type, payload, created_at) VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.ExecContext(ctx, outboxQuery, uuid.New(), id, "OrderCreated", eventPayload, createdAt); err != nil {
+			tx.Rollback()
+			logger.Error().Err(err).Msg("Failed to insert outbox event")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			logger.Error().Err(err).Msg("Failed to commit transaction")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		logger.Info().
+			Str("order_id", id.String()).
+			Str("customer", req.CustomerName).
+			Msg("Order created")
+
+		c.Header(subscriptionTokenHeader, subscriptionToken)
+		c.Data(http.StatusCreated, "application/json", respBody)
+		return
 	}
-	go func() {
-		s.eventQueue <- event.OrderCreated{Order: resp}
-	}()
-	c.JSON(http.StatusCreated, resp)
 }
 
 // GetOrderById handles GET /orders/{id}
@@ -103,9 +197,10 @@ func (s *Server) GetOrderById(c *gin.Context, id openapi_types.UUID) {
 	logger := s.logger.With().Str("handler", "GetOrderById").Logger()
 
 	var order api.OrderResponse
-	query := `SELECT id, customer_name, item, created_at FROM orders WHERE id = $1`
+	var status api.OrderStatus
+	query := `SELECT id, customer_name, item, created_at, status FROM orders WHERE id = $1`
 	row := s.db.QueryRowContext(c.Request.Context(), query, id)
-	err := row.Scan(&order.Id, &order.CustomerName, &order.Item, &order.CreatedAt)
+	err := row.Scan(&order.Id, &order.CustomerName, &order.Item, &order.CreatedAt, &status)
 	if err == sql.ErrNoRows {
 		logger.Error().Err(err).Msg("Order not found")
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
@@ -115,6 +210,7 @@ func (s *Server) GetOrderById(c *gin.Context, id openapi_types.UUID) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order"})
 		return
 	}
+	order.Status = &status
 
 	logger.Info().
 		Str("order_id", id.String()).
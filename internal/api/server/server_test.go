@@ -21,6 +21,10 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// testSubscriptionSecret signs subscription tokens in tests; production uses
+// subscriptionTokenSecret() instead (see cmd/orders/main.go).
+var testSubscriptionSecret = []byte("test-subscription-secret")
+
 func setupServerWithMockDB(t *testing.T) (*Server, sqlmock.Sqlmock, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
 	db, mock, err := sqlmock.New()
@@ -28,19 +32,26 @@ func setupServerWithMockDB(t *testing.T) (*Server, sqlmock.Sqlmock, context.Canc
 		t.Fatalf("failed to create sqlmock: %v", err)
 	}
 	logger := zerolog.Nop()
-	ch := make(chan event.OrderCreated, 100)
-	eventWorker := event.NewEventWorker(ch, logger)
+	ch := make(chan event.Event, 100)
+	eventWorker := event.NewEventWorker(ch, 2, time.Second, logger)
 	eventWorker.StartEventWorker(ctx)
-	srv := NewServer(db, ch, logger)
+	srv := NewServer(db, eventWorker, testSubscriptionSecret, logger)
 	return srv, mock, cancel
 }
 
 func setupGinTestContext(body string) (*gin.Context, *httptest.ResponseRecorder) {
+	return setupGinTestContextWithIdempotencyKey(body, "")
+}
+
+func setupGinTestContextWithIdempotencyKey(body, idempotencyKey string) (*gin.Context, *httptest.ResponseRecorder) {
 	gin.SetMode(gin.TestMode)
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
 	c.Request.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		c.Request.Header.Set(idempotencyHeader, idempotencyKey)
+	}
 	return c, w
 }
 
@@ -66,17 +77,15 @@ func TestCreateOrder_Success(t *testing.T) {
 
 	c, w := setupGinTestContext(reqBody)
 
-	// Expect query for existing order by customerName, item, createdAt (example)
-	rows := sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at"})
-
-	mock.ExpectQuery(`SELECT .* FROM orders WHERE customer_name.*`).
-		WithArgs("John Doe", "Book", sqlmock.AnyArg()).
-		WillReturnRows(rows)
-
-	// Mock DB Exec for order insertion
+	// Order insert and its outbox event insert happen in the same transaction
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO orders").
-		WithArgs(sqlmock.AnyArg(), "John Doe", "Book", sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "John Doe", "Book", sqlmock.AnyArg(), "OPEN").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "OrderCreated", sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	srv.CreateOrder(c)
 
@@ -115,56 +124,151 @@ func TestCreateOrder_DBError(t *testing.T) {
 
 	c, w := setupGinTestContext(reqBody)
 
+	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO orders").
-		WithArgs(sqlmock.AnyArg(), "John Doe", "Book", sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "John Doe", "Book", sqlmock.AnyArg(), "OPEN").
 		WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
 
 	srv.CreateOrder(c)
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
-func TestCreateOrder_IdempotencyFound(t *testing.T) {
+func TestCreateOrder_WithIdempotencyKey_PersistsResponse(t *testing.T) {
 	srv, mock, cancel := setupServerWithMockDB(t)
 	defer cancel()
 	reqBody := `{"customer_name": "John Doe", "item": "Book"}`
 
-	c, w := setupGinTestContext(reqBody)
+	c, w := setupGinTestContextWithIdempotencyKey(reqBody, "key-123")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO idempotency_keys").
+		WithArgs("John Doe", "key-123", sqlmock.AnyArg(), http.StatusCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO orders").
+		WithArgs(sqlmock.AnyArg(), "John Doe", "Book", sqlmock.AnyArg(), "OPEN").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "OrderCreated", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	srv.CreateOrder(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestCreateOrder_IdempotencyKeyReplaysStoredResponse(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	reqBody := `{"customer_name": "John Doe", "item": "Book"}`
+
+	c, w := setupGinTestContextWithIdempotencyKey(reqBody, "key-123")
 
 	orderID := uuid.New()
-	createdAt := time.Now()
+	storedResp, err := json.Marshal(api.OrderResponse{
+		Id:           &orderID,
+		CustomerName: strPtr("John Doe"),
+		Item:         strPtr("Book"),
+		CreatedAt:    timePtr(time.Now().UTC()),
+	})
+	assert.NoError(t, err)
 
-	// Expect query for existing order by customerName, item, createdAt (example)
-	rows := sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at"}).
-		AddRow(orderID, "John Doe", "Book", createdAt)
+	rows := sqlmock.NewRows([]string{"body_hash", "status_code", "response_body"}).
+		AddRow(hashRequestBody([]byte(reqBody)), http.StatusCreated, storedResp)
 
-	mock.ExpectQuery(`SELECT .* FROM orders WHERE customer_name.*`).
-		WithArgs("John Doe", "Book", sqlmock.AnyArg()).
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO idempotency_keys").
+		WithArgs("John Doe", "key-123", sqlmock.AnyArg(), http.StatusCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT body_hash, status_code, response_body FROM idempotency_keys WHERE customer_name = .* AND key = .* FOR UPDATE`).
+		WithArgs("John Doe", "key-123").
 		WillReturnRows(rows)
+	mock.ExpectRollback()
 
 	srv.CreateOrder(c)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusCreated, w.Code)
 
 	var resp api.OrderResponse
-	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-
-	assert.NotNil(t, resp.CustomerName)
-	assert.Equal(t, "John Doe", *resp.CustomerName)
-	assert.Equal(t, "Book", *resp.Item)
 	assert.Equal(t, orderID.String(), resp.Id.String())
 }
 
+func TestCreateOrder_IdempotencyKeyWithDifferentBody(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	reqBody := `{"customer_name": "John Doe", "item": "Book"}`
+
+	c, w := setupGinTestContextWithIdempotencyKey(reqBody, "key-123")
+
+	rows := sqlmock.NewRows([]string{"body_hash", "status_code", "response_body"}).
+		AddRow("some-other-hash", http.StatusCreated, []byte(`{}`))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO idempotency_keys").
+		WithArgs("John Doe", "key-123", sqlmock.AnyArg(), http.StatusCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT body_hash, status_code, response_body FROM idempotency_keys WHERE customer_name = .* AND key = .* FOR UPDATE`).
+		WithArgs("John Doe", "key-123").
+		WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	srv.CreateOrder(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestCreateOrder_IdempotencyKeyRetriesAfterConcurrentRollback(t *testing.T) {
+	srv, mock, cancel := setupServerWithMockDB(t)
+	defer cancel()
+	reqBody := `{"customer_name": "John Doe", "item": "Book"}`
+
+	c, w := setupGinTestContextWithIdempotencyKey(reqBody, "key-123")
+
+	// First attempt loses the claim race; by the time we lock the row the
+	// concurrent holder has rolled back, so the key is free again and we
+	// retry our own claim, which now succeeds.
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO idempotency_keys").
+		WithArgs("John Doe", "key-123", sqlmock.AnyArg(), http.StatusCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT body_hash, status_code, response_body FROM idempotency_keys WHERE customer_name = .* AND key = .* FOR UPDATE`).
+		WithArgs("John Doe", "key-123").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO idempotency_keys").
+		WithArgs("John Doe", "key-123", sqlmock.AnyArg(), http.StatusCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO orders").
+		WithArgs(sqlmock.AnyArg(), "John Doe", "Book", sqlmock.AnyArg(), "OPEN").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "OrderCreated", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	srv.CreateOrder(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func strPtr(s string) *string        { return &s }
+func timePtr(t time.Time) *time.Time { return &t }
+
 func TestGetOrderById_Success(t *testing.T) {
 	srv, mock, cancel := setupServerWithMockDB(t)
 	defer cancel()
 
 	orderID := uuid.New()
-	rows := sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at"}).
-		AddRow(orderID, "John Doe", "Book", time.Now())
+	rows := sqlmock.NewRows([]string{"id", "customer_name", "item", "created_at", "status"}).
+		AddRow(orderID, "John Doe", "Book", time.Now(), "OPEN")
 
-	mock.ExpectQuery("SELECT id, customer_name, item, created_at FROM orders WHERE id =").
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id =").
 		WithArgs(orderID).
 		WillReturnRows(rows)
 
@@ -192,7 +296,7 @@ func TestGetOrderById_NotFound(t *testing.T) {
 	defer cancel()
 	orderID := uuid.New()
 
-	mock.ExpectQuery("SELECT id, customer_name, item, created_at FROM orders WHERE id =").
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id =").
 		WithArgs(orderID).
 		WillReturnError(sql.ErrNoRows)
 
@@ -220,7 +324,7 @@ func TestGetOrderById_DBError(t *testing.T) {
 	defer cancel()
 	orderID := uuid.New()
 
-	mock.ExpectQuery("SELECT id, customer_name, item, created_at FROM orders WHERE id =").
+	mock.ExpectQuery("SELECT id, customer_name, item, created_at, status FROM orders WHERE id =").
 		WithArgs(orderID).
 		WillReturnError(errors.New("db error"))
 
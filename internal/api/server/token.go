@@ -0,0 +1,50 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+)
+
+// mintSubscriptionToken returns an opaque, server-signed token scoping a
+// WebSocket subscription to customerName. CreateOrder hands it back to
+// whoever creates an order under that name; SubscribeOrders and
+// SubscribeOrderByID require it instead of accepting a bare customer name,
+// so knowing a customer's name (which appears in plain text in every order
+// response) is no longer enough on its own to read their event stream.
+func mintSubscriptionToken(secret []byte, customerName string) string {
+	encodedName := base64.RawURLEncoding.EncodeToString([]byte(customerName))
+	return encodedName + "." + signCustomerName(secret, customerName)
+}
+
+// verifySubscriptionToken checks a token minted by mintSubscriptionToken and
+// returns the customer name it's valid for. ok is false if token is
+// missing, malformed, or doesn't verify against secret.
+func verifySubscriptionToken(secret []byte, token string) (customerName string, ok bool) {
+	encodedName, sig, found := strings.Cut(token, ".")
+	if !found || encodedName == "" || sig == "" {
+		return "", false
+	}
+
+	nameBytes, err := base64.RawURLEncoding.DecodeString(encodedName)
+	if err != nil {
+		return "", false
+	}
+	customerName = string(nameBytes)
+
+	want := signCustomerName(secret, customerName)
+	if subtle.ConstantTimeCompare([]byte(sig), []byte(want)) != 1 {
+		return "", false
+	}
+	return customerName, true
+}
+
+// signCustomerName returns the base64url-encoded HMAC-SHA256 of
+// customerName under secret.
+func signCustomerName(secret []byte, customerName string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(customerName))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
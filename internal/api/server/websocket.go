@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/v2/internal/event"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = (wsPongWait * 9) / 10
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// SubscribeOrders handles GET /ws/orders. Clients are subscribed to every
+// OrderCreated, OrderUpdated, and OrderCancelled event for the customer name
+// their subscription token (see mintSubscriptionToken) is valid for. Events
+// are delivered once the OutboxPoller has durably published them, so expect
+// up to one OUTBOX_POLL_INTERVAL (2s by default) of latency, not sub-second
+// delivery.
+func (s *Server) SubscribeOrders(c *gin.Context) {
+	customerName, ok := s.authenticateSubscriber(c)
+	if !ok {
+		return
+	}
+	s.subscribeWebSocket(c, event.SubscriptionFilter{CustomerName: customerName})
+}
+
+// SubscribeOrderByID handles GET /ws/orders/:id, narrowing the subscription
+// to events for a single order belonging to the authenticated customer.
+func (s *Server) SubscribeOrderByID(c *gin.Context, id openapi_types.UUID) {
+	customerName, ok := s.authenticateSubscriber(c)
+	if !ok {
+		return
+	}
+	orderID := uuid.UUID(id)
+	s.subscribeWebSocket(c, event.SubscriptionFilter{
+		CustomerName: customerName,
+		OrderID:      &orderID,
+	})
+}
+
+// authenticateSubscriber validates the subscription token passed via the
+// Authorization header or a "token" query param (WebSocket clients can't
+// always set headers) and returns the customer name it was minted for. On
+// failure it writes the 401 response itself and returns ok=false.
+func (s *Server) authenticateSubscriber(c *gin.Context) (string, bool) {
+	token := c.Query("token")
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+
+	customerName, ok := verifySubscriptionToken(s.subscriptionSecret, token)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid subscription token"})
+		return "", false
+	}
+	return customerName, true
+}
+
+func (s *Server) subscribeWebSocket(c *gin.Context, filter event.SubscriptionFilter) {
+	logger := s.logger.With().Str("handler", "SubscribeOrders").Logger()
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("WebSocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	sub := s.eventWorker.Subscribe(filter)
+	defer s.eventWorker.Unsubscribe(sub)
+
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		return nil
+	})
+
+	// The client isn't expected to send anything; read in the background
+	// purely to process control frames (pong/close) and notice disconnects.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case evt, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(evt); err != nil {
+				logger.Debug().Err(err).Msg("WebSocket write failed, closing connection")
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/v2/internal/api"
+	"example.com/v2/internal/event"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSubscribeOrders_ReceivesCreatedEvent exercises the fan-out hub
+// directly: an event fed onto the internal queue (as the OutboxPoller does
+// once it has durably published it) must reach a subscribed WebSocket
+// client.
+func TestSubscribeOrders_ReceivesCreatedEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := zerolog.Nop()
+	ch := make(chan event.Event, 10)
+	eventWorker := event.NewEventWorker(ch, 2, time.Second, logger)
+	eventWorker.StartEventWorker(ctx)
+	srv := NewServer(nil, eventWorker, testSubscriptionSecret, logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws/orders", srv.SubscribeOrders)
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	token := mintSubscriptionToken(testSubscriptionSecret, "John Doe")
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws/orders?token=" + token
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Give the subscription a moment to register before the event fires.
+	time.Sleep(50 * time.Millisecond)
+
+	orderID := uuid.New()
+	customerName := "John Doe"
+	item := "Book"
+	createdAt := time.Now().UTC()
+	ch <- event.Event{Type: "OrderCreated", Order: api.OrderResponse{
+		Id:           &orderID,
+		CustomerName: &customerName,
+		Item:         &item,
+		CreatedAt:    &createdAt,
+	}}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var received event.Event
+	require.NoError(t, conn.ReadJSON(&received))
+
+	assert.Equal(t, "OrderCreated", received.Type)
+	require.NotNil(t, received.Order.CustomerName)
+	assert.Equal(t, "John Doe", *received.Order.CustomerName)
+	require.NotNil(t, received.Order.Item)
+	assert.Equal(t, "Book", *received.Order.Item)
+}
+
+// TestOrderCreated_DeliversOverWebSocketViaOutbox exercises the real
+// end-to-end path: POST /orders writes the order and its outbox row and
+// mints the caller's subscription token, the client subscribes with that
+// token, and an OutboxPoller.PollOnce call (standing in for its next
+// ticker-driven poll) publishes the row and fans it out to the connected
+// WebSocket client.
+func TestOrderCreated_DeliversOverWebSocketViaOutbox(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	logger := zerolog.Nop()
+	ch := make(chan event.Event, 10)
+	eventWorker := event.NewEventWorker(ch, 2, time.Second, logger)
+	eventWorker.StartEventWorker(ctx)
+	srv := NewServer(db, eventWorker, testSubscriptionSecret, logger)
+	poller := event.NewOutboxPoller(db, event.NewLogPublisher(logger), eventWorker, time.Second, logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders", srv.CreateOrder)
+	router.GET("/ws/orders", srv.SubscribeOrders)
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO orders").
+		WithArgs(sqlmock.AnyArg(), "John Doe", "Book", sqlmock.AnyArg(), "OPEN").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "OrderCreated", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	createResp, err := http.Post(ts.URL+"/orders", "application/json", strings.NewReader(`{"customer_name": "John Doe", "item": "Book"}`))
+	require.NoError(t, err)
+	defer createResp.Body.Close()
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+
+	var created api.OrderResponse
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&created))
+	token := createResp.Header.Get(subscriptionTokenHeader)
+	require.NotEmpty(t, token)
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws/orders?token=" + token
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Give the subscription a moment to register before the poll fires.
+	time.Sleep(50 * time.Millisecond)
+
+	eventID := uuid.New()
+	payload, err := json.Marshal(event.PublishedEvent{
+		Type:         "OrderCreated",
+		OrderID:      created.Id.String(),
+		CustomerName: "John Doe",
+		Item:         "Book",
+		CreatedAt:    *created.CreatedAt,
+		EventID:      eventID.String(),
+		OccurredAt:   time.Now().UTC(),
+	})
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT id FROM order_events").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(eventID))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, aggregate_id, type, payload").
+		WithArgs(eventID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "aggregate_id", "type", "payload"}).
+			AddRow(eventID, uuid.UUID(*created.Id), "OrderCreated", payload))
+	mock.ExpectExec("UPDATE order_events SET published_at").
+		WithArgs(eventID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, poller.PollOnce(context.Background()))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var received event.Event
+	require.NoError(t, conn.ReadJSON(&received))
+
+	assert.Equal(t, "OrderCreated", received.Type)
+	require.NotNil(t, received.Order.CustomerName)
+	assert.Equal(t, "John Doe", *received.Order.CustomerName)
+	require.NotNil(t, received.Order.Id)
+	assert.Equal(t, created.Id.String(), received.Order.Id.String())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscribeOrders_RequiresToken(t *testing.T) {
+	srv, _, cancel := setupServerWithMockDB(t)
+	defer cancel()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws/orders", srv.SubscribeOrders)
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/ws/orders")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
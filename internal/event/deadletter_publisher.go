@@ -0,0 +1,87 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	deadLetterMaxRetries = 3
+	deadLetterBaseDelay  = 100 * time.Millisecond
+)
+
+// DeadLetterPublisher wraps an EventPublisher with exponential-backoff
+// retries. If every retry fails, the event is appended as a JSON line to a
+// local dead-letter file instead of being lost.
+type DeadLetterPublisher struct {
+	inner          EventPublisher
+	deadLetterPath string
+	logger         zerolog.Logger
+}
+
+func NewDeadLetterPublisher(inner EventPublisher, deadLetterPath string, logger zerolog.Logger) *DeadLetterPublisher {
+	return &DeadLetterPublisher{
+		inner:          inner,
+		deadLetterPath: deadLetterPath,
+		logger:         logger.With().Str("component", "DeadLetterPublisher").Logger(),
+	}
+}
+
+func (p *DeadLetterPublisher) Publish(ctx context.Context, evt PublishedEvent) error {
+	var lastErr error
+	delay := deadLetterBaseDelay
+
+retryLoop:
+	for attempt := 1; attempt <= deadLetterMaxRetries; attempt++ {
+		if lastErr = p.inner.Publish(ctx, evt); lastErr == nil {
+			return nil
+		}
+		p.logger.Warn().Err(lastErr).
+			Str("event_id", evt.EventID).
+			Int("attempt", attempt).
+			Msg("Event publish failed, retrying")
+
+		if attempt == deadLetterMaxRetries {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			lastErr = ctx.Err()
+			break retryLoop
+		}
+		delay *= 2
+	}
+
+	p.logger.Error().Err(lastErr).
+		Str("event_id", evt.EventID).
+		Msg("Event sink unreachable after retries, writing to dead-letter file")
+	return p.writeDeadLetter(evt)
+}
+
+func (p *DeadLetterPublisher) writeDeadLetter(evt PublishedEvent) error {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal dead-letter event: %w", err)
+	}
+
+	f, err := os.OpenFile(p.deadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open dead-letter file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(payload, '\n')); err != nil {
+		return fmt.Errorf("write dead-letter event: %w", err)
+	}
+	return nil
+}
+
+func (p *DeadLetterPublisher) Close() error {
+	return p.inner.Close()
+}
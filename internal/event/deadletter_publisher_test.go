@@ -0,0 +1,63 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	failures int
+	calls    int
+}
+
+func (f *fakePublisher) Publish(_ context.Context, _ PublishedEvent) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("sink unreachable")
+	}
+	return nil
+}
+
+func (f *fakePublisher) Close() error { return nil }
+
+func TestDeadLetterPublisher_SucceedsAfterTransientFailure(t *testing.T) {
+	inner := &fakePublisher{failures: 2}
+	deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.log")
+	publisher := NewDeadLetterPublisher(inner, deadLetterPath, zerolog.Nop())
+
+	err := publisher.Publish(context.Background(), PublishedEvent{EventID: "evt-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.calls)
+
+	_, statErr := os.Stat(deadLetterPath)
+	assert.True(t, os.IsNotExist(statErr), "dead-letter file should not be written on eventual success")
+}
+
+func TestDeadLetterPublisher_WritesDeadLetterAfterExhaustingRetries(t *testing.T) {
+	inner := &fakePublisher{failures: deadLetterMaxRetries}
+	deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.log")
+	publisher := NewDeadLetterPublisher(inner, deadLetterPath, zerolog.Nop())
+
+	err := publisher.Publish(context.Background(), PublishedEvent{EventID: "evt-2", OrderID: "order-2"})
+	require.NoError(t, err)
+
+	f, err := os.Open(deadLetterPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan())
+
+	var written PublishedEvent
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &written))
+	assert.Equal(t, "evt-2", written.EventID)
+}
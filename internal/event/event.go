@@ -2,43 +2,205 @@ package event
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"example.com/v2/internal/api"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog"
 )
 
-type OrderCreated struct {
-	Order api.OrderResponse
+// Event is the in-process envelope fanned out to WebSocket subscribers. Type
+// is one of "OrderCreated", "OrderUpdated", "OrderCancelled".
+type Event struct {
+	Type  string            `json:"type"`
+	Order api.OrderResponse `json:"order"`
 }
+
+// subscriberBufferSize bounds how many undelivered events a single
+// subscriber can have queued before it is considered a slow consumer.
+const subscriberBufferSize = 16
+
+var (
+	eventsEnqueuedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "events_enqueued_total",
+		Help: "Total number of events successfully enqueued onto the event worker pool.",
+	})
+	eventsDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "events_dropped_total",
+		Help: "Total number of events dropped because the queue stayed full until the enqueue timeout elapsed.",
+	})
+	eventsProcessedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "events_processed_total",
+		Help: "Total number of events processed (fanned out to subscribers) by the event worker pool.",
+	})
+)
+
+// SubscriptionFilter narrows the events a subscriber receives. An empty
+// CustomerName matches every customer; a nil OrderID matches every order.
+type SubscriptionFilter struct {
+	CustomerName string
+	OrderID      *uuid.UUID
+}
+
+func (f SubscriptionFilter) matches(o api.OrderResponse) bool {
+	if f.CustomerName != "" && (o.CustomerName == nil || *o.CustomerName != f.CustomerName) {
+		return false
+	}
+	if f.OrderID != nil && (o.Id == nil || uuid.UUID(*o.Id) != *f.OrderID) {
+		return false
+	}
+	return true
+}
+
+// Subscription is a subscriber's handle into the hub. Events is closed once
+// Unsubscribe is called.
+type Subscription struct {
+	ID     uuid.UUID
+	Events chan Event
+	filter SubscriptionFilter
+}
+
 type EventWorker interface {
+	// StartEventWorker launches the worker pool's goroutines. They run until
+	// ctx is cancelled.
 	StartEventWorker(ctx context.Context)
+	// TryEnqueue attempts to hand evt to the worker pool without blocking
+	// indefinitely: if the queue is still full when enqueueTimeout elapses
+	// (or ctx is cancelled first), the event is dropped and an error is
+	// returned.
+	TryEnqueue(ctx context.Context, evt Event) error
+	// Drain waits for in-flight events to finish processing and every
+	// worker goroutine to exit, up to timeout, after StartEventWorker's ctx
+	// has been cancelled.
+	Drain(timeout time.Duration)
+	// Subscribe registers a new subscriber matching filter and returns its
+	// handle. Callers must call Unsubscribe once they are done reading.
+	Subscribe(filter SubscriptionFilter) *Subscription
+	// Unsubscribe removes a subscriber and closes its event channel.
+	Unsubscribe(sub *Subscription)
 }
 
 type eventWorker struct {
-	eventQueue chan OrderCreated
-	logger     zerolog.Logger
+	eventQueue     chan Event
+	poolSize       int
+	enqueueTimeout time.Duration
+	logger         zerolog.Logger
+	wg             sync.WaitGroup
+
+	mu   sync.RWMutex
+	subs map[uuid.UUID]*Subscription
 }
 
-func NewEventWorker(eventQueue chan OrderCreated, logger zerolog.Logger) EventWorker {
+// NewEventWorker builds the in-process fan-out hub that pushes events from
+// eventQueue out to WebSocket subscribers, using a pool of poolSize worker
+// goroutines. enqueueTimeout bounds how long TryEnqueue will wait for room in
+// eventQueue before dropping the event. Reliable delivery to external sinks
+// is handled separately by the OutboxPoller.
+func NewEventWorker(eventQueue chan Event, poolSize int, enqueueTimeout time.Duration, logger zerolog.Logger) EventWorker {
 	return &eventWorker{
-		eventQueue: eventQueue,
-		logger:     logger,
+		eventQueue:     eventQueue,
+		poolSize:       poolSize,
+		enqueueTimeout: enqueueTimeout,
+		logger:         logger,
+		subs:           make(map[uuid.UUID]*Subscription),
+	}
+}
+
+func (e *eventWorker) Subscribe(filter SubscriptionFilter) *Subscription {
+	sub := &Subscription{
+		ID:     uuid.New(),
+		Events: make(chan Event, subscriberBufferSize),
+		filter: filter,
+	}
+
+	e.mu.Lock()
+	e.subs[sub.ID] = sub
+	e.mu.Unlock()
+
+	return sub
+}
+
+func (e *eventWorker) Unsubscribe(sub *Subscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.subs[sub.ID]; ok {
+		delete(e.subs, sub.ID)
+		close(sub.Events)
+	}
+}
+
+func (e *eventWorker) TryEnqueue(ctx context.Context, evt Event) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, e.enqueueTimeout)
+	defer cancel()
+
+	select {
+	case e.eventQueue <- evt:
+		eventsEnqueuedTotal.Inc()
+		return nil
+	case <-timeoutCtx.Done():
+		eventsDroppedTotal.Inc()
+		return fmt.Errorf("enqueue event: %w", timeoutCtx.Err())
 	}
 }
 
 func (e *eventWorker) StartEventWorker(ctx context.Context) {
 	logger := e.logger.With().Str("worker", "EventWorker").Logger()
-	go func() {
-		for {
-			select {
-			case e := <-e.eventQueue:
-				// Simulate notification/email
-				// Use structured logging or other logic here
-				logger.Info().Msgf("Processing event: OrderCreated: %+v\n", e.Order)
-			case <-ctx.Done():
-				logger.Info().Msgf("Shutting down event worker")
-				return
+
+	for i := 0; i < e.poolSize; i++ {
+		e.wg.Add(1)
+		go func(workerID int) {
+			defer e.wg.Done()
+			for {
+				select {
+				case evt := <-e.eventQueue:
+					logger.Info().Str("type", evt.Type).Msgf("Processing event: %+v", evt.Order)
+					e.dispatch(evt)
+					eventsProcessedTotal.Inc()
+				case <-ctx.Done():
+					logger.Info().Int("worker_id", workerID).Msg("Shutting down event worker")
+					return
+				}
 			}
-		}
+		}(i)
+	}
+}
+
+func (e *eventWorker) Drain(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
 	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		e.logger.Warn().Msg("Event worker drain timed out, some in-flight events may not have been processed")
+	}
+}
+
+// dispatch fans evt out to every subscriber whose filter matches it. A
+// subscriber with a full buffer is considered a slow consumer: the event is
+// dropped for that subscriber rather than blocking the whole hub.
+func (e *eventWorker) dispatch(evt Event) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, sub := range e.subs {
+		if !sub.filter.matches(evt.Order) {
+			continue
+		}
+		select {
+		case sub.Events <- evt:
+		default:
+			e.logger.Warn().
+				Str("subscriber_id", sub.ID.String()).
+				Msg("Slow WebSocket subscriber, dropping event")
+		}
+	}
 }
@@ -0,0 +1,59 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/v2/internal/api"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWorker_TryEnqueue_DropsWhenQueueFull(t *testing.T) {
+	queue := make(chan Event, 1)
+	worker := NewEventWorker(queue, 1, 20*time.Millisecond, zerolog.Nop())
+
+	// Fill the queue without starting the pool, so nothing drains it.
+	require.NoError(t, worker.TryEnqueue(context.Background(), Event{Type: "OrderCreated"}))
+
+	err := worker.TryEnqueue(context.Background(), Event{Type: "OrderCreated"})
+	assert.Error(t, err)
+}
+
+func TestEventWorker_DispatchesToMatchingSubscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := make(chan Event, 10)
+	worker := NewEventWorker(queue, 2, time.Second, zerolog.Nop())
+	worker.StartEventWorker(ctx)
+
+	customerName := "John Doe"
+	sub := worker.Subscribe(SubscriptionFilter{CustomerName: customerName})
+	defer worker.Unsubscribe(sub)
+
+	require.NoError(t, worker.TryEnqueue(ctx, Event{
+		Type:  "OrderCreated",
+		Order: api.OrderResponse{CustomerName: &customerName},
+	}))
+
+	select {
+	case evt := <-sub.Events:
+		assert.Equal(t, "OrderCreated", evt.Type)
+	case <-time.After(time.Second):
+		t.Fatal("expected subscriber to receive the dispatched event")
+	}
+}
+
+func TestEventWorker_DrainWaitsForWorkersToExit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	queue := make(chan Event, 1)
+	worker := NewEventWorker(queue, 2, time.Second, zerolog.Nop())
+	worker.StartEventWorker(ctx)
+
+	cancel()
+	worker.Drain(time.Second)
+}
@@ -0,0 +1,47 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// kafkaWriter is the subset of *kafka.Writer used by KafkaPublisher, kept as
+// an interface so tests can substitute a fake broker.
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaPublisher publishes events to a Kafka topic.
+type KafkaPublisher struct {
+	writer kafkaWriter
+}
+
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Topic:    topic,
+			Balancer: &kafka.LeastBytes{},
+		},
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, evt PublishedEvent) error {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	msg := kafka.Message{Key: []byte(evt.OrderID), Value: payload}
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("publish to kafka: %w", err)
+	}
+	return nil
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,52 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKafkaWriter is a minimal stand-in for *kafka.Writer that records the
+// messages it was asked to write, in place of a real broker.
+type fakeKafkaWriter struct {
+	written []kafka.Message
+	err     error
+}
+
+func (f *fakeKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.written = append(f.written, msgs...)
+	return nil
+}
+
+func (f *fakeKafkaWriter) Close() error { return nil }
+
+func TestKafkaPublisher_Publish(t *testing.T) {
+	fake := &fakeKafkaWriter{}
+	publisher := &KafkaPublisher{writer: fake}
+
+	evt := PublishedEvent{Type: "OrderCreated", OrderID: "order-1", CustomerName: "John Doe", Item: "Book"}
+	require.NoError(t, publisher.Publish(context.Background(), evt))
+
+	require.Len(t, fake.written, 1)
+	assert.Equal(t, "order-1", string(fake.written[0].Key))
+
+	var received PublishedEvent
+	require.NoError(t, json.Unmarshal(fake.written[0].Value, &received))
+	assert.Equal(t, evt, received)
+}
+
+func TestKafkaPublisher_Publish_BrokerUnreachable(t *testing.T) {
+	fake := &fakeKafkaWriter{err: errors.New("dial tcp: connection refused")}
+	publisher := &KafkaPublisher{writer: fake}
+
+	err := publisher.Publish(context.Background(), PublishedEvent{Type: "OrderCreated", OrderID: "order-1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,189 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"example.com/v2/internal/api"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// outboxBatchSize bounds how many unpublished rows a single poll picks up.
+const outboxBatchSize = 100
+
+// OutboxPoller periodically publishes unpublished rows from the
+// order_events outbox table to an EventPublisher, marking them published
+// once delivery succeeds. Selecting with FOR UPDATE SKIP LOCKED makes it
+// safe to run one poller per replica concurrently.
+type OutboxPoller struct {
+	db          *sql.DB
+	publisher   EventPublisher
+	eventWorker EventWorker
+	interval    time.Duration
+	logger      zerolog.Logger
+}
+
+// NewOutboxPoller builds a poller that publishes outbox rows via publisher
+// and, once published, hands them to eventWorker so they can still be fanned
+// out to live WebSocket subscribers.
+func NewOutboxPoller(db *sql.DB, publisher EventPublisher, eventWorker EventWorker, interval time.Duration, logger zerolog.Logger) *OutboxPoller {
+	return &OutboxPoller{
+		db:          db,
+		publisher:   publisher,
+		eventWorker: eventWorker,
+		interval:    interval,
+		logger:      logger.With().Str("component", "OutboxPoller").Logger(),
+	}
+}
+
+// Start runs the poll loop in a background goroutine until ctx is cancelled.
+func (p *OutboxPoller) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.PollOnce(ctx); err != nil {
+					p.logger.Error().Err(err).Msg("Outbox poll failed")
+				}
+			case <-ctx.Done():
+				p.logger.Info().Msg("Shutting down outbox poller")
+				return
+			}
+		}
+	}()
+}
+
+type outboxRow struct {
+	id          uuid.UUID
+	aggregateID uuid.UUID
+	eventType   string
+	payload     []byte
+}
+
+// PollOnce processes up to outboxBatchSize unpublished rows, oldest first.
+// Candidate ids are read without locking, then each row is locked,
+// published, and marked published in its own short transaction by pollOne.
+// A single transaction spanning the whole batch would keep every row locked
+// for as long as the slowest publish takes to retry (DeadLetterPublisher
+// makes up to deadLetterMaxRetries attempts with backoff), stalling other
+// unpublished rows and other replicas' pollers; per-row transactions bound
+// that to one row at a time. Start calls this on a timer; it's exported so
+// tests (and anything else that needs deterministic, on-demand delivery)
+// can trigger a poll directly instead of waiting out the interval.
+func (p *OutboxPoller) PollOnce(ctx context.Context) error {
+	ids, err := p.candidateRowIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("select outbox candidates: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := p.pollOne(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// candidateRowIDs returns up to outboxBatchSize unpublished row ids, oldest
+// first, without locking them. pollOne re-checks and locks each one
+// individually, so a row already claimed by a concurrent poller by the time
+// it's processed is simply skipped rather than blocking this one.
+func (p *OutboxPoller) candidateRowIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT id FROM order_events
+		WHERE published_at IS NULL
+		ORDER BY created_at
+		LIMIT $1`, outboxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// pollOne locks, publishes, and marks published a single outbox row inside
+// its own transaction. It's a no-op if the row was already published or is
+// locked by a concurrent poller by the time it runs.
+func (p *OutboxPoller) pollOne(ctx context.Context, id uuid.UUID) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var r outboxRow
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, aggregate_id, type, payload
+		FROM order_events
+		WHERE id = $1 AND published_at IS NULL
+		FOR UPDATE SKIP LOCKED`, id)
+	if err := row.Scan(&r.id, &r.aggregateID, &r.eventType, &r.payload); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("select outbox row: %w", err)
+	}
+
+	var published PublishedEvent
+	if err := json.Unmarshal(r.payload, &published); err != nil {
+		p.logger.Error().Err(err).Str("event_id", r.id.String()).Msg("Failed to unmarshal outbox payload, skipping")
+		return nil
+	}
+
+	if err := p.publisher.Publish(ctx, published); err != nil {
+		p.logger.Warn().Err(err).Str("event_id", r.id.String()).Msg("Failed to publish outbox event, will retry next poll")
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE order_events SET published_at = now() WHERE id = $1`, r.id); err != nil {
+		return fmt.Errorf("mark outbox event published: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit outbox event: %w", err)
+	}
+
+	p.notifySubscribers(ctx, r.eventType, published)
+	return nil
+}
+
+// notifySubscribers hands a durably-published event to the event worker
+// pool so live WebSocket subscribers see it. Delivery is best-effort: if the
+// pool's queue stays full until the enqueue timeout elapses, the event is
+// dropped for live subscribers but remains correctly recorded as published.
+func (p *OutboxPoller) notifySubscribers(ctx context.Context, eventType string, published PublishedEvent) {
+	orderID, err := uuid.Parse(published.OrderID)
+	if err != nil {
+		p.logger.Error().Err(err).Str("event_id", published.EventID).Msg("Failed to parse order id from outbox payload")
+		return
+	}
+
+	order := api.OrderResponse{
+		Id:           &orderID,
+		CustomerName: &published.CustomerName,
+		Item:         &published.Item,
+		CreatedAt:    &published.CreatedAt,
+	}
+	if published.Status != "" {
+		status := api.OrderStatus(published.Status)
+		order.Status = &status
+	}
+
+	if err := p.eventWorker.TryEnqueue(ctx, Event{Type: eventType, Order: order}); err != nil {
+		p.logger.Warn().Err(err).Str("event_id", published.EventID).Msg("Dropping live WebSocket notification")
+	}
+}
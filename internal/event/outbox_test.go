@@ -0,0 +1,103 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type flakyPublisher struct {
+	failTimes int
+	calls     int
+	published []PublishedEvent
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, evt PublishedEvent) error {
+	p.calls++
+	if p.calls <= p.failTimes {
+		return errors.New("sink unreachable")
+	}
+	p.published = append(p.published, evt)
+	return nil
+}
+
+func (p *flakyPublisher) Close() error { return nil }
+
+func TestOutboxPoller_PublishFailureIsRetriedOnNextPoll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	eventID := uuid.New()
+	orderID := uuid.New()
+	payload, err := json.Marshal(PublishedEvent{
+		Type:         "OrderCreated",
+		OrderID:      orderID.String(),
+		CustomerName: "John Doe",
+		Item:         "Book",
+		CreatedAt:    time.Now().UTC(),
+		EventID:      eventID.String(),
+		OccurredAt:   time.Now().UTC(),
+	})
+	require.NoError(t, err)
+
+	eventQueue := make(chan Event, 1)
+	eventWorker := NewEventWorker(eventQueue, 1, time.Second, zerolog.Nop())
+	publisher := &flakyPublisher{failTimes: 1}
+	poller := NewOutboxPoller(db, publisher, eventWorker, time.Second, zerolog.Nop())
+
+	// First poll: the sink is unreachable, so the row stays unpublished and
+	// its per-row transaction is rolled back, releasing the row lock.
+	mock.ExpectQuery("SELECT id FROM order_events").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(eventID))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, aggregate_id, type, payload").
+		WithArgs(eventID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "aggregate_id", "type", "payload"}).
+			AddRow(eventID, orderID, "OrderCreated", payload))
+	mock.ExpectRollback()
+
+	require.NoError(t, poller.PollOnce(context.Background()))
+	require.Equal(t, 1, publisher.calls)
+	require.Empty(t, publisher.published)
+
+	select {
+	case <-eventQueue:
+		t.Fatal("event should not be forwarded until it is durably published")
+	default:
+	}
+
+	// Second poll: the sink is back up, so the row is published and marked
+	// as such in its own transaction.
+	mock.ExpectQuery("SELECT id FROM order_events").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(eventID))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, aggregate_id, type, payload").
+		WithArgs(eventID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "aggregate_id", "type", "payload"}).
+			AddRow(eventID, orderID, "OrderCreated", payload))
+	mock.ExpectExec("UPDATE order_events SET published_at").
+		WithArgs(eventID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, poller.PollOnce(context.Background()))
+	require.Equal(t, 2, publisher.calls)
+	require.Len(t, publisher.published, 1)
+
+	select {
+	case evt := <-eventQueue:
+		require.Equal(t, "John Doe", *evt.Order.CustomerName)
+	default:
+		t.Fatal("expected the published event to be forwarded for live WebSocket fan-out")
+	}
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,90 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"example.com/v2/internal/api"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// EventPublisher delivers a PublishedEvent to an external sink (log, Redis,
+// Kafka, ...). Implementations should be safe for concurrent use.
+type EventPublisher interface {
+	Publish(ctx context.Context, evt PublishedEvent) error
+	Close() error
+}
+
+// PublishedEvent is the stable JSON schema written to every event sink,
+// independent of the internal Event envelope used for WebSocket fan-out.
+type PublishedEvent struct {
+	Type         string    `json:"type"`
+	OrderID      string    `json:"order_id"`
+	CustomerName string    `json:"customer_name"`
+	Item         string    `json:"item"`
+	Status       string    `json:"status,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	EventID      string    `json:"event_id"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// newPublishedEvent builds the published schema for an order domain event.
+func newPublishedEvent(eventType string, order api.OrderResponse) PublishedEvent {
+	out := PublishedEvent{
+		Type:       eventType,
+		EventID:    uuid.New().String(),
+		OccurredAt: time.Now().UTC(),
+	}
+	if order.Id != nil {
+		out.OrderID = uuid.UUID(*order.Id).String()
+	}
+	if order.CustomerName != nil {
+		out.CustomerName = *order.CustomerName
+	}
+	if order.Item != nil {
+		out.Item = *order.Item
+	}
+	if order.Status != nil {
+		out.Status = string(*order.Status)
+	}
+	if order.CreatedAt != nil {
+		out.CreatedAt = *order.CreatedAt
+	}
+	return out
+}
+
+// NewOrderCreatedPayload returns the stable JSON payload stored in the
+// order_events outbox table for an OrderCreated event.
+func NewOrderCreatedPayload(order api.OrderResponse) ([]byte, error) {
+	return json.Marshal(newPublishedEvent("OrderCreated", order))
+}
+
+// NewOrderUpdatedPayload returns the stable JSON payload stored in the
+// order_events outbox table for an OrderUpdated event.
+func NewOrderUpdatedPayload(order api.OrderResponse) ([]byte, error) {
+	return json.Marshal(newPublishedEvent("OrderUpdated", order))
+}
+
+// NewOrderCancelledPayload returns the stable JSON payload stored in the
+// order_events outbox table for an OrderCancelled event.
+func NewOrderCancelledPayload(order api.OrderResponse) ([]byte, error) {
+	return json.Marshal(newPublishedEvent("OrderCancelled", order))
+}
+
+// LogPublisher is the original in-memory behavior: it only logs the event.
+type LogPublisher struct {
+	logger zerolog.Logger
+}
+
+func NewLogPublisher(logger zerolog.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger.With().Str("sink", "log").Logger()}
+}
+
+func (p *LogPublisher) Publish(_ context.Context, evt PublishedEvent) error {
+	p.logger.Info().Msgf("Processing event: %+v", evt)
+	return nil
+}
+
+func (p *LogPublisher) Close() error { return nil }
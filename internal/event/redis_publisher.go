@@ -0,0 +1,34 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPublisher publishes events to a Redis pub/sub channel.
+type RedisPublisher struct {
+	client  *redis.Client
+	channel string
+}
+
+func NewRedisPublisher(client *redis.Client, channel string) *RedisPublisher {
+	return &RedisPublisher{client: client, channel: channel}
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, evt PublishedEvent) error {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	if err := p.client.Publish(ctx, p.channel, payload).Err(); err != nil {
+		return fmt.Errorf("publish to redis channel %q: %w", p.channel, err)
+	}
+	return nil
+}
+
+func (p *RedisPublisher) Close() error {
+	return p.client.Close()
+}
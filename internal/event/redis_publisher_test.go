@@ -0,0 +1,48 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisPublisher_Publish(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	sub := client.Subscribe(context.Background(), "orders.events")
+	defer sub.Close()
+	_, err = sub.Receive(context.Background())
+	require.NoError(t, err)
+
+	publisher := NewRedisPublisher(client, "orders.events")
+	evt := PublishedEvent{
+		Type:         "OrderCreated",
+		OrderID:      uuid.New().String(),
+		CustomerName: "John Doe",
+		Item:         "Book",
+		CreatedAt:    time.Now().UTC(),
+		EventID:      uuid.New().String(),
+		OccurredAt:   time.Now().UTC(),
+	}
+
+	require.NoError(t, publisher.Publish(context.Background(), evt))
+
+	msg, err := sub.ReceiveMessage(context.Background())
+	require.NoError(t, err)
+
+	var received PublishedEvent
+	require.NoError(t, json.Unmarshal([]byte(msg.Payload), &received))
+	require.Equal(t, evt.OrderID, received.OrderID)
+	require.Equal(t, evt.CustomerName, received.CustomerName)
+}